@@ -0,0 +1,293 @@
+// Command dgen is a CLI front end for the dgen library: it derives a
+// deterministic password from an input value and a character-set policy, or
+// verifies a candidate password against that same policy.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tedla-brandsema/dgen"
+)
+
+// errVerifyMismatch is returned by run when --verify's candidate does not
+// match the regenerated password. main treats it like any other error
+// returned from run: print it and exit 1.
+var errVerifyMismatch = errors.New("candidate does not match the derived password")
+
+// groupSpec maps a --groups name to the CharGroup it builds, so buildCharSet
+// can look names up in a stable order regardless of the order the caller
+// listed them in.
+type groupSpec struct {
+	name string
+	spec dgen.CharGroup
+}
+
+var knownGroups = []groupSpec{
+	{"lower", dgen.CharGroup{Name: "lowercase", Chars: "abcdefghijkmnopqrstuvwxyz", Required: true}},
+	{"upper", dgen.CharGroup{Name: "uppercase", Chars: "ABCDEFGHJKLMNPQRSTUVWXYZ", Required: true}},
+	{"digit", dgen.CharGroup{Name: "digits", Chars: "123456789", Required: true}},
+	{"special", dgen.CharGroup{Name: "special", Chars: "!@#$%^&*()-_=+[]{};:,.<>/?", Required: true}},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "dgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("dgen", flag.ContinueOnError)
+
+	length := fs.Int("length", envIntOrDefault("DGEN_LENGTH", 16), "password length")
+	minUpper := fs.Int("min-upper", envIntOrDefault("DGEN_MIN_UPPER", 0), "minimum uppercase characters")
+	minLower := fs.Int("min-lower", envIntOrDefault("DGEN_MIN_LOWER", 0), "minimum lowercase characters")
+	minDigit := fs.Int("min-digit", envIntOrDefault("DGEN_MIN_DIGIT", 0), "minimum digit characters")
+	minSpecial := fs.Int("min-special", envIntOrDefault("DGEN_MIN_SPECIAL", 0), "minimum special characters")
+	exclude := fs.String("exclude", envOrDefault("DGEN_EXCLUDE", ""), "characters to exclude from the output")
+	groups := fs.String("groups", envOrDefault("DGEN_GROUPS", "lower,upper,digit,special"), "comma-separated character groups to draw from")
+	pronounceable := fs.Bool("pronounceable", envBoolOrDefault("DGEN_PRONOUNCEABLE", false), "generate a human-pronounceable password instead")
+	input := fs.String("input", envOrDefault("DGEN_INPUT", ""), "the input value to derive the password from")
+	inputFile := fs.String("input-file", envOrDefault("DGEN_INPUT_FILE", ""), "read the input value from this file instead of --input")
+	count := fs.Int("count", envIntOrDefault("DGEN_COUNT", 1), "number of passwords to generate")
+	format := fs.String("format", envOrDefault("DGEN_FORMAT", "raw"), "output format: raw, json, or mobile")
+	verify := fs.String("verify", "", "path to a file containing a candidate password to verify instead of generating one")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *count < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", *count)
+	}
+
+	in, err := readInput(*input, *inputFile)
+	if err != nil {
+		return err
+	}
+
+	charSet, err := buildCharSet(*groups, *minLower, *minUpper, *minDigit, *minSpecial, *exclude)
+	if err != nil {
+		return err
+	}
+
+	if *verify != "" {
+		if *pronounceable {
+			return fmt.Errorf("--verify is not supported together with --pronounceable")
+		}
+		candidate, err := os.ReadFile(*verify)
+		if err != nil {
+			return fmt.Errorf("reading candidate file: %w", err)
+		}
+		ok, err := dgen.Verify(in, charSet, *length, trimTrailingNewline(candidate))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errVerifyMismatch
+		}
+		return nil
+	}
+
+	for i := 0; i < *count; i++ {
+		pass, err := generate(in, charSet, *length, *pronounceable, *count, i)
+		if err != nil {
+			return err
+		}
+		out, err := formatOutput(pass, *format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, out)
+	}
+
+	return nil
+}
+
+// generate derives the i-th password for a --count run. When count is 1 the
+// input is used as-is so single-password output matches calling the library
+// directly; for count > 1 each index is folded into the HKDF context so the
+// passwords are distinct but still fully deterministic.
+func generate(input []byte, charSet *dgen.CharSet, length int, pronounceable bool, count, index int) ([]byte, error) {
+	var opts []dgen.Option
+	if count > 1 {
+		opts = append(opts, dgen.WithContext([]byte(fmt.Sprintf("dgen-cli-index-%d", index))))
+	}
+	if pronounceable {
+		if inject := injectedGroups(charSet); len(inject) > 0 {
+			opts = append(opts, dgen.WithInjectedGroups(inject))
+		}
+		return dgen.EncodePronounceable(input, length, opts...)
+	}
+	return dgen.Encode(input, charSet, length, opts...)
+}
+
+// injectedGroups picks out the groups from charSet that carry an explicit
+// --min-* minimum, so --pronounceable can still honor --min-upper/-lower/
+// -digit/-special via WithInjectedGroups instead of silently ignoring them.
+// Each group's Chars is filtered through charSet.Exclude first, the same way
+// dgen.Encode filters its groups. For pronounceable output --exclude applies
+// only to these injected characters; the syllable letters are not filtered.
+func injectedGroups(charSet *dgen.CharSet) []dgen.CharGroup {
+	var inject []dgen.CharGroup
+	for _, group := range charSet.Groups {
+		if group.Min > 0 {
+			group.Chars = filterExcluded(group.Chars, charSet.Exclude)
+			inject = append(inject, group)
+		}
+	}
+	return inject
+}
+
+// filterExcluded returns chars with every rune present in exclude removed,
+// mirroring dgen's own exclusion filtering.
+func filterExcluded(chars, exclude string) string {
+	if exclude == "" {
+		return chars
+	}
+	var b strings.Builder
+	b.Grow(len(chars))
+	for _, r := range chars {
+		if strings.ContainsRune(exclude, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// readInput resolves the --input/--input-file flags into the raw input bytes
+// Encode should derive a password from.
+func readInput(input, inputFile string) ([]byte, error) {
+	if input != "" && inputFile != "" {
+		return nil, fmt.Errorf("--input and --input-file are mutually exclusive")
+	}
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading input file: %w", err)
+		}
+		return trimTrailingNewline(data), nil
+	}
+	if input == "" {
+		return nil, fmt.Errorf("one of --input or --input-file is required")
+	}
+	return []byte(input), nil
+}
+
+// buildCharSet assembles a CharSet from the --groups list and per-group
+// minimums, applying --exclude to the result.
+func buildCharSet(groupList string, minLower, minUpper, minDigit, minSpecial int, exclude string) (*dgen.CharSet, error) {
+	mins := map[string]int{
+		"lower":   minLower,
+		"upper":   minUpper,
+		"digit":   minDigit,
+		"special": minSpecial,
+	}
+
+	requested := make(map[string]bool)
+	for _, name := range strings.Split(groupList, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		requested[name] = true
+	}
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("--groups must name at least one group")
+	}
+
+	charSet := &dgen.CharSet{Exclude: exclude}
+	for _, known := range knownGroups {
+		if !requested[known.name] {
+			delete(requested, known.name)
+			continue
+		}
+		delete(requested, known.name)
+		group := known.spec
+		group.Min = mins[known.name]
+		charSet.Groups = append(charSet.Groups, group)
+	}
+	for unknown := range requested {
+		return nil, fmt.Errorf("unknown group %q; want one of lower, upper, digit, special", unknown)
+	}
+
+	return charSet, nil
+}
+
+// formatOutput renders pass according to format: "raw" prints the password
+// as-is, "json" emits {"password": "...", "length": N}, and "mobile" inserts
+// a separator every 4 characters for easier typing on phones.
+func formatOutput(pass []byte, format string) (string, error) {
+	switch format {
+	case "", "raw":
+		return string(pass), nil
+	case "json":
+		data, err := json.Marshal(struct {
+			Password string `json:"password"`
+			Length   int    `json:"length"`
+		}{Password: string(pass), Length: len(pass)})
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case "mobile":
+		return groupForMobile(pass, 4, "-"), nil
+	default:
+		return "", fmt.Errorf("unknown format %q; want raw, json, or mobile", format)
+	}
+}
+
+// groupForMobile inserts sep after every groupSize characters of pass.
+func groupForMobile(pass []byte, groupSize int, sep string) string {
+	var b strings.Builder
+	for i, c := range pass {
+		if i > 0 && i%groupSize == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func trimTrailingNewline(data []byte) []byte {
+	return []byte(strings.TrimRight(string(data), "\r\n"))
+}
+
+func envOrDefault(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+func envIntOrDefault(key string, def int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func envBoolOrDefault(key string, def bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildCharSet(t *testing.T) {
+	charSet, err := buildCharSet("lower,digit", 0, 0, 3, 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(charSet.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(charSet.Groups))
+	}
+	for _, g := range charSet.Groups {
+		if g.Name == "digits" && g.Min != 3 {
+			t.Errorf("expected digits group to have Min 3, got %d", g.Min)
+		}
+	}
+}
+
+func TestBuildCharSetUnknownGroup(t *testing.T) {
+	if _, err := buildCharSet("lower,bogus", 0, 0, 0, 0, ""); err == nil {
+		t.Error("expected an error for an unknown group name")
+	}
+}
+
+func TestBuildCharSetEmptyGroups(t *testing.T) {
+	if _, err := buildCharSet("", 0, 0, 0, 0, ""); err == nil {
+		t.Error("expected an error when no groups are requested")
+	}
+}
+
+func TestBuildCharSetAppliesExclude(t *testing.T) {
+	charSet, err := buildCharSet("lower", 0, 0, 0, 0, "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if charSet.Exclude != "abc" {
+		t.Errorf("expected Exclude %q, got %q", "abc", charSet.Exclude)
+	}
+}
+
+func TestReadInputMutuallyExclusive(t *testing.T) {
+	if _, err := readInput("a", "b"); err == nil {
+		t.Error("expected an error when both --input and --input-file are set")
+	}
+}
+
+func TestReadInputRequired(t *testing.T) {
+	if _, err := readInput("", ""); err == nil {
+		t.Error("expected an error when neither --input nor --input-file is set")
+	}
+}
+
+func TestReadInputFromFlag(t *testing.T) {
+	got, err := readInput("hello", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", got)
+	}
+}
+
+func TestFormatOutput(t *testing.T) {
+	pass := []byte("ab12CD34")
+
+	raw, err := formatOutput(pass, "raw")
+	if err != nil || raw != "ab12CD34" {
+		t.Errorf("raw: got %q, err %v", raw, err)
+	}
+
+	jsonOut, err := formatOutput(pass, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(jsonOut, `"password":"ab12CD34"`) || !strings.Contains(jsonOut, `"length":8`) {
+		t.Errorf("json output missing expected fields: %s", jsonOut)
+	}
+
+	mobile, err := formatOutput(pass, "mobile")
+	if err != nil || mobile != "ab12-CD34" {
+		t.Errorf("mobile: got %q, err %v", mobile, err)
+	}
+
+	if _, err := formatOutput(pass, "bogus"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestGroupForMobile(t *testing.T) {
+	got := groupForMobile([]byte("abcdefgh"), 4, "-")
+	if got != "abcd-efgh" {
+		t.Errorf("expected %q, got %q", "abcd-efgh", got)
+	}
+}
+
+func TestRunGeneratesDeterministicOutput(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	args := []string{"--input", "cli-test", "--length", "12"}
+
+	if err := run(args, &buf1); err != nil {
+		t.Fatalf("first run error: %v", err)
+	}
+	if err := run(args, &buf2); err != nil {
+		t.Fatalf("second run error: %v", err)
+	}
+	if buf1.String() != buf2.String() {
+		t.Errorf("non-deterministic CLI output: %q vs %q", buf1.String(), buf2.String())
+	}
+}
+
+func TestRunPronounceableHonorsMinFlags(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"--input", "test123", "--pronounceable", "--min-digit", "3", "--length", "16"}
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pass := strings.TrimRight(buf.String(), "\n")
+	digits := 0
+	for _, c := range pass {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	if digits < 3 {
+		t.Errorf("password %q has %d digits, want at least 3 given --min-digit 3", pass, digits)
+	}
+}
+
+func TestInjectedGroupsOnlyIncludesGroupsWithMin(t *testing.T) {
+	charSet, err := buildCharSet("lower,upper,digit,special", 0, 0, 2, 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inject := injectedGroups(charSet)
+	if len(inject) != 1 || inject[0].Name != "digits" {
+		t.Errorf("expected only the digits group to be injected, got %+v", inject)
+	}
+}
+
+func TestInjectedGroupsAppliesExclude(t *testing.T) {
+	charSet, err := buildCharSet("digit", 0, 0, 2, 0, "0123456789")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inject := injectedGroups(charSet)
+	if len(inject) != 1 || inject[0].Chars != "" {
+		t.Errorf("expected the digits group's Chars to be fully excluded, got %+v", inject)
+	}
+}
+
+func TestRunPronounceableExcludeEmptiesInjectedGroup(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"--input", "testinput", "--pronounceable", "--min-digit", "2", "--exclude", "0123456789", "--length", "12"}
+	if err := run(args, &buf); err == nil {
+		t.Error("expected an error when --exclude empties an injected group's characters")
+	}
+}
+
+func TestRunPronounceableHonorsExcludeWithMinFlags(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"--input", "testinput", "--pronounceable", "--min-digit", "2", "--exclude", "0123456", "--length", "12"}
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pass := strings.TrimRight(buf.String(), "\n")
+	if strings.ContainsAny(pass, "0123456") {
+		t.Errorf("password %q contains an excluded digit", pass)
+	}
+}
+
+func TestRunVerifyMatch(t *testing.T) {
+	var genBuf bytes.Buffer
+	genArgs := []string{"--input", "verify-cli", "--length", "12"}
+	if err := run(genArgs, &genBuf); err != nil {
+		t.Fatalf("unexpected error generating candidate: %v", err)
+	}
+
+	candidateFile := filepath.Join(t.TempDir(), "candidate.txt")
+	if err := os.WriteFile(candidateFile, genBuf.Bytes(), 0o600); err != nil {
+		t.Fatalf("unexpected error writing candidate file: %v", err)
+	}
+
+	var verifyBuf bytes.Buffer
+	verifyArgs := []string{"--input", "verify-cli", "--length", "12", "--verify", candidateFile}
+	if err := run(verifyArgs, &verifyBuf); err != nil {
+		t.Errorf("expected no error for a matching candidate, got %v", err)
+	}
+}
+
+func TestRunVerifyMismatch(t *testing.T) {
+	candidateFile := filepath.Join(t.TempDir(), "candidate.txt")
+	if err := os.WriteFile(candidateFile, []byte("not-the-right-password"), 0o600); err != nil {
+		t.Fatalf("unexpected error writing candidate file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	args := []string{"--input", "verify-cli", "--length", "12", "--verify", candidateFile}
+	err := run(args, &buf)
+	if !errors.Is(err, errVerifyMismatch) {
+		t.Errorf("expected errVerifyMismatch, got %v", err)
+	}
+}
+
+func TestRunCount(t *testing.T) {
+	var buf bytes.Buffer
+	args := []string{"--input", "cli-count", "--length", "10", "--count", "3"}
+
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines of output, got %d", len(lines))
+	}
+	if lines[0] == lines[1] || lines[1] == lines[2] {
+		t.Errorf("expected distinct passwords per count index, got %v", lines)
+	}
+}
@@ -1,34 +1,100 @@
-package main
+package dgen
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/hkdf"
 )
 
 type CharGroup struct {
 	Name     string
 	Chars    string
 	Required bool
+	// Min is the minimum number of characters Encode must draw from this
+	// group. It only applies when Required is true; a Required group with
+	// Min == 0 defaults to 1, preserving the original "at least one" behavior.
+	Min int
 }
 
 type CharSet struct {
 	Groups []CharGroup
+	// Exclude lists characters that must never appear in an Encode result,
+	// regardless of which group they came from (for example "Il1O0" to drop
+	// visually ambiguous characters, or a site's own denylist).
+	Exclude string
+}
+
+// ambiguousChars are characters that are easily confused with one another in
+// many fonts: capital I, lowercase l, digit 1, capital O, digit 0.
+const ambiguousChars = "Il1O0"
+
+// quoteChars are quote characters that commonly break naive shell quoting or
+// CSV escaping when embedded in a generated password.
+const quoteChars = "'\"`"
+
+// WithoutAmbiguous adds visually ambiguous characters (Il1O0) to cs's
+// exclusion set and returns cs for chaining, e.g. DefaultCharSet().WithoutAmbiguous().
+func (cs *CharSet) WithoutAmbiguous() *CharSet {
+	cs.Exclude += ambiguousChars
+	return cs
+}
+
+// WithoutQuotes adds quote characters (', ", `) to cs's exclusion set and
+// returns cs for chaining.
+func (cs *CharSet) WithoutQuotes() *CharSet {
+	cs.Exclude += quoteChars
+	return cs
+}
+
+// filterChars returns chars with every rune present in exclude removed.
+func filterChars(chars, exclude string) string {
+	if exclude == "" {
+		return chars
+	}
+	var b strings.Builder
+	b.Grow(len(chars))
+	for _, r := range chars {
+		if strings.ContainsRune(exclude, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// groupMin returns the effective minimum draw count for a required group,
+// defaulting to 1 so existing CharSets keep their "at least one" behavior.
+func groupMin(group CharGroup) int {
+	if group.Min == 0 {
+		return 1
+	}
+	return group.Min
 }
 
 func DefaultCharSet() *CharSet {
 	return &CharSet{
 		Groups: []CharGroup{
-			{"lowercase", "abcdefghijkmnopqrstuvwxyz", true},
-			{"uppercase", "ABCDEFGHJKLMNPQRSTUVWXYZ", true},
-			{"digits", "123456789", true},
-			{"special", "!@#$%^&*()-_=+[]{};:,.<>/?", true},
+			{Name: "lowercase", Chars: "abcdefghijkmnopqrstuvwxyz", Required: true},
+			{Name: "uppercase", Chars: "ABCDEFGHJKLMNPQRSTUVWXYZ", Required: true},
+			{Name: "digits", Chars: "123456789", Required: true},
+			{Name: "special", Chars: "!@#$%^&*()-_=+[]{};:,.<>/?", Required: true},
 		},
 	}
 }
 
 // SimplePRNG is a deterministic pseudorandom number generator using a xorshift32 algorithm.
 // This implementation is explicitly defined to ensure identical results on any platform.
+//
+// SimplePRNG only seeds from 32 bits of the input hash and biases Intn whenever n
+// doesn't divide 2^32 evenly. It is kept for callers that depend on passwords
+// generated by earlier versions of dgen; pass WithLegacyPRNG to Encode to use it.
 type SimplePRNG struct {
 	state uint32
 }
@@ -55,35 +121,200 @@ func (prng *SimplePRNG) Intn(n int) int {
 	return int(prng.next() % uint32(n))
 }
 
-// Deterministically shuffle the result using the Fisher–Yates algorithm.
-func shuffle(chars []byte, prng *SimplePRNG) {
+func hashFunction(input []byte) uint32 {
+	hash := sha256.Sum256(input)
+	seed := binary.BigEndian.Uint32(hash[:4])
+	return seed
+}
+
+// randSource is the minimal interface Encode needs from a deterministic
+// number generator. Both SimplePRNG and chachaStream satisfy it.
+type randSource interface {
+	Intn(n int) int
+}
+
+// chachaStream is the default randSource for Encode. It draws from a ChaCha20
+// keystream seeded by the full 32-byte key produced by deriveKey, and picks
+// values via rejection sampling so every outcome in [0, n) is equally likely.
+type chachaStream struct {
+	cipher *chacha20.Cipher
+}
+
+// newChachaStream creates a chachaStream seeded by key. The nonce is fixed at
+// zero: key already uniquely determines the stream for a given input, salt
+// and context, so no additional nonce is needed to keep Encode deterministic.
+func newChachaStream(key [32]byte) *chachaStream {
+	nonce := make([]byte, chacha20.NonceSize)
+	cipher, err := chacha20.NewUnauthenticatedCipher(key[:], nonce)
+	if err != nil {
+		// key and nonce are always sized correctly above, so this cannot fail.
+		panic(fmt.Sprintf("dgen: creating chacha20 cipher: %v", err))
+	}
+	return &chachaStream{cipher: cipher}
+}
+
+// readBits draws the next k bits from the keystream, most significant byte first.
+func (s *chachaStream) readBits(k int) uint64 {
+	n := (k + 7) / 8
+	buf := make([]byte, n)
+	s.cipher.XORKeyStream(buf, buf)
+
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v & (1<<uint(k) - 1)
+}
+
+// Intn returns an unbiased pseudorandom integer in the range [0, n). It reads
+// k = ceil(log2(n)) bits at a time from the keystream and rejects any draw
+// past the largest multiple of n representable in k bits, so groups whose
+// size isn't a power of two are still sampled uniformly.
+func (s *chachaStream) Intn(n int) int {
+	if n <= 0 {
+		panic("dgen: Intn called with n <= 0")
+	}
+	if n == 1 {
+		return 0
+	}
+
+	k := bits.Len(uint(n - 1))
+	limit := (uint64(1) << uint(k)) / uint64(n) * uint64(n)
+	for {
+		v := s.readBits(k)
+		if v < limit {
+			return int(v % uint64(n))
+		}
+	}
+}
+
+// deriveKey derives a 32-byte ChaCha20 key from input using HKDF-SHA256, with
+// salt and context folded in as the HKDF salt and info parameters.
+func deriveKey(input, salt, context []byte) ([32]byte, error) {
+	var key [32]byte
+	kdf := hkdf.New(sha256.New, input, salt, context)
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return key, fmt.Errorf("deriving key: %w", err)
+	}
+	return key, nil
+}
+
+// shuffle deterministically shuffles chars in place using the Fisher–Yates algorithm.
+func shuffle(chars []byte, source randSource) {
 	for i := len(chars) - 1; i > 0; i-- {
-		j := prng.Intn(i + 1)
+		j := source.Intn(i + 1)
 		chars[i], chars[j] = chars[j], chars[i]
 	}
 }
 
-func hashFunction(input []byte) uint32 {
-	hash := sha256.Sum256(input)
-	seed := binary.BigEndian.Uint32(hash[:4])
-	return seed
+// options holds the configuration gathered from the Option values passed to
+// Encode and EncodePronounceable.
+type options struct {
+	legacyPRNG   bool
+	salt         []byte
+	context      []byte
+	injectGroups []CharGroup
+	hasRange     bool
+	minLen       int
+	maxLen       int
 }
 
-func Encode(input []byte, charSet *CharSet, limit int) ([]byte, error) {
-	seed := hashFunction(input)
-	prng := NewSimplePRNG(seed)
+// Option configures the behavior of Encode and EncodePronounceable.
+type Option func(*options)
+
+// WithLegacyPRNG makes Encode use the original xorshift32-based SimplePRNG
+// instead of the HKDF/ChaCha20 stream, for callers that depend on passwords
+// generated by earlier versions of dgen.
+func WithLegacyPRNG() Option {
+	return func(o *options) {
+		o.legacyPRNG = true
+	}
+}
+
+// WithSalt sets the HKDF salt used when deriving the ChaCha20 key. It has no
+// effect when combined with WithLegacyPRNG.
+func WithSalt(salt []byte) Option {
+	return func(o *options) {
+		o.salt = salt
+	}
+}
+
+// WithContext sets the HKDF info parameter used when deriving the ChaCha20
+// key, letting callers domain-separate passwords derived from the same input
+// (for example, by site name or account). It has no effect when combined
+// with WithLegacyPRNG.
+func WithContext(context []byte) Option {
+	return func(o *options) {
+		o.context = context
+	}
+}
+
+// Range makes Encode pick its output length deterministically from the
+// seeded stream, uniformly within [min, max] (inclusive), instead of using
+// the limit argument; the limit argument is ignored in that case, so 0 is
+// conventional, e.g. Encode(input, charSet, 0, Range(12, 20)). The same input
+// and options still yield the same password and the same length every time.
+func Range(min, max int) Option {
+	return func(o *options) {
+		o.hasRange = true
+		o.minLen = min
+		o.maxLen = max
+	}
+}
+
+// newRandSource builds the randSource Encode and EncodePronounceable draw
+// from, honoring cfg.legacyPRNG to fall back to SimplePRNG.
+func newRandSource(input []byte, cfg *options) (randSource, error) {
+	if cfg.legacyPRNG {
+		return NewSimplePRNG(hashFunction(input)), nil
+	}
+	key, err := deriveKey(input, cfg.salt, cfg.context)
+	if err != nil {
+		return nil, err
+	}
+	return newChachaStream(key), nil
+}
+
+func Encode(input []byte, charSet *CharSet, limit int, opts ...Option) ([]byte, error) {
+	cfg := &options{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	source, err := newRandSource(input, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.hasRange {
+		if cfg.minLen < 0 || cfg.maxLen < cfg.minLen {
+			return nil, fmt.Errorf("invalid length range [%d, %d]", cfg.minLen, cfg.maxLen)
+		}
+		limit = cfg.minLen + source.Intn(cfg.maxLen-cfg.minLen+1)
+	}
+
+	// Filter each group's characters through the exclusion set once, before
+	// any PRNG draw, so determinism is preserved across versions given the
+	// same exclusion configuration.
+	groups := charSet.Groups
+	if charSet.Exclude != "" {
+		groups = make([]CharGroup, len(charSet.Groups))
+		for i, group := range charSet.Groups {
+			group.Chars = filterChars(group.Chars, charSet.Exclude)
+			groups[i] = group
+		}
+	}
 
 	union := ""
 	// select one random character for each required group
-	requiredChars := make([]byte, 0, len(charSet.Groups))
-	for _, group := range charSet.Groups {
+	totalMin := 0
+	for _, group := range groups {
 		union += group.Chars
 		if group.Required {
 			if len(group.Chars) == 0 {
 				return nil, fmt.Errorf("required group %q has no characters", group.Name)
 			}
-			idx := prng.Intn(len(group.Chars))
-			requiredChars = append(requiredChars, group.Chars[idx])
+			totalMin += groupMin(group)
 		}
 	}
 
@@ -91,20 +322,47 @@ func Encode(input []byte, charSet *CharSet, limit int) ([]byte, error) {
 		return nil, fmt.Errorf("no characters available: union is empty")
 	}
 
-	if limit < len(requiredChars) {
-		return nil, fmt.Errorf("password length %d is too short; must be at least %d", limit, len(requiredChars))
+	if limit < totalMin {
+		return nil, fmt.Errorf("password length %d is too short; required groups need at least %d characters", limit, totalMin)
+	}
+
+	// Draw each required group's minimum count of characters.
+	requiredChars := make([]byte, 0, totalMin)
+	for _, group := range groups {
+		if !group.Required {
+			continue
+		}
+		for i := 0; i < groupMin(group); i++ {
+			idx := source.Intn(len(group.Chars))
+			requiredChars = append(requiredChars, group.Chars[idx])
+		}
 	}
 
 	// Fill the remaining positions using the union
 	remainingLength := limit - len(requiredChars)
 	remainingChars := make([]byte, remainingLength)
 	for i := 0; i < remainingLength; i++ {
-		idx := prng.Intn(len(union))
+		idx := source.Intn(len(union))
 		remainingChars[i] = union[idx]
 	}
 
 	passwordChars := append(requiredChars, remainingChars...)
-	shuffle(passwordChars, prng)
+	shuffle(passwordChars, source)
 
 	return passwordChars, nil
 }
+
+// Verify regenerates the password Encode(input, charSet, limit, opts...)
+// would produce and compares it against candidate in constant time. This
+// lets applications built on dgen validate a user-supplied password derived
+// from input without ever storing it.
+func Verify(input []byte, charSet *CharSet, limit int, candidate []byte, opts ...Option) (bool, error) {
+	want, err := Encode(input, charSet, limit, opts...)
+	if err != nil {
+		return false, err
+	}
+	if len(want) != len(candidate) {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare(want, candidate) == 1, nil
+}
@@ -5,6 +5,23 @@ import (
 	"testing"
 )
 
+// checkDeterminismWithOpts is like checkDeterminism but forwards Options, so
+// tests can exercise the legacy PRNG and the default ChaCha20 stream alike.
+func checkDeterminismWithOpts(t *testing.T, input []byte, charSet *CharSet, limit int, opts ...Option) []byte {
+	pass1, err1 := Encode(input, charSet, limit, opts...)
+	if err1 != nil {
+		t.Fatalf("first call error: %v", err1)
+	}
+	pass2, err2 := Encode(input, charSet, limit, opts...)
+	if err2 != nil {
+		t.Fatalf("second call error: %v", err2)
+	}
+	if string(pass1) != string(pass2) {
+		t.Errorf("non-deterministic output: %q vs %q", pass1, pass2)
+	}
+	return pass1
+}
+
 // testCase defines the input and expected behavior for a test.
 type testCase struct {
 	name          string
@@ -92,8 +109,8 @@ func TestEncodeWithComplexity(t *testing.T) {
 			input: []byte("test"),
 			charSet: &CharSet{
 				Groups: []CharGroup{
-					{"lowercase", "", true}, // Required but empty
-					{"uppercase", "ABCDEFGHIJKLMNOPQRSTUVWXYZ", true},
+					{Name: "lowercase", Chars: "", Required: true}, // Required but empty
+					{Name: "uppercase", Chars: "ABCDEFGHIJKLMNOPQRSTUVWXYZ", Required: true},
 				},
 			},
 			limit:   6,
@@ -104,9 +121,9 @@ func TestEncodeWithComplexity(t *testing.T) {
 			input: []byte("custom-test"),
 			charSet: &CharSet{
 				Groups: []CharGroup{
-					{"vowels", "aeiou", true},
-					{"consonants", "bcdfghjklmnpqrstvwxyz", true},
-					{"numbers", "0123456789", false},
+					{Name: "vowels", Chars: "aeiou", Required: true},
+					{Name: "consonants", Chars: "bcdfghjklmnpqrstvwxyz", Required: true},
+					{Name: "numbers", Chars: "0123456789", Required: false},
 				},
 			},
 			limit:         10,
@@ -131,3 +148,288 @@ func TestEncodeWithComplexity(t *testing.T) {
 		})
 	}
 }
+
+// checkGroupMinimums ensures that the generated password contains at least
+// Min characters from every required group (falling back to 1 when Min is
+// unset), analogous to checkRequiredGroups.
+func checkGroupMinimums(t *testing.T, pass []byte, charSet *CharSet) {
+	for _, group := range charSet.Groups {
+		if !group.Required {
+			continue
+		}
+		min := group.Min
+		if min == 0 {
+			min = 1
+		}
+		got := 0
+		for _, c := range pass {
+			if strings.ContainsRune(group.Chars, rune(c)) {
+				got++
+			}
+		}
+		if got < min {
+			t.Errorf("password %q has %d characters from group %q, want at least %d", pass, got, group.Name, min)
+		}
+	}
+}
+
+func TestEncodeGroupMinimums(t *testing.T) {
+	charSet := &CharSet{
+		Groups: []CharGroup{
+			{Name: "lowercase", Chars: "abcdefghijklmnopqrstuvwxyz", Required: true, Min: 3},
+			{Name: "digits", Chars: "0123456789", Required: true, Min: 2},
+			{Name: "special", Chars: "!@#$%", Required: true, Min: 1},
+		},
+	}
+
+	pass, err := Encode([]byte("group-minimums"), charSet, 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkPasswordLength(t, pass, 12)
+	checkGroupMinimums(t, pass, charSet)
+}
+
+func TestEncodeGroupMinimumsExceedLimit(t *testing.T) {
+	charSet := &CharSet{
+		Groups: []CharGroup{
+			{Name: "lowercase", Chars: "abcdefghijklmnopqrstuvwxyz", Required: true, Min: 10},
+			{Name: "digits", Chars: "0123456789", Required: true, Min: 10},
+		},
+	}
+
+	_, err := Encode([]byte("too-small"), charSet, 5)
+	if err == nil {
+		t.Fatalf("expected an error when group minimums exceed the password length")
+	}
+}
+
+func TestEncodeExcludeFiltersCharacters(t *testing.T) {
+	charSet := &CharSet{
+		Groups: []CharGroup{
+			{Name: "lowercase", Chars: "abcdefghijklmnopqrstuvwxyz", Required: true},
+			{Name: "digits", Chars: "0123456789", Required: true},
+		},
+		Exclude: "abc012",
+	}
+
+	pass, err := Encode([]byte("exclude-check"), charSet, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkPasswordLength(t, pass, 20)
+	if strings.ContainsAny(string(pass), charSet.Exclude) {
+		t.Errorf("password %q contains an excluded character from %q", pass, charSet.Exclude)
+	}
+}
+
+func TestEncodeExcludeEmptiesRequiredGroup(t *testing.T) {
+	charSet := &CharSet{
+		Groups: []CharGroup{
+			{Name: "digits", Chars: "01", Required: true},
+		},
+		Exclude: "01",
+	}
+
+	if _, err := Encode([]byte("exclude-all"), charSet, 8); err == nil {
+		t.Error("expected an error when exclusion empties a required group")
+	}
+}
+
+func TestCharSetWithoutAmbiguous(t *testing.T) {
+	charSet := (&CharSet{
+		Groups: []CharGroup{
+			{Name: "mixed", Chars: "Il1O0abc23", Required: true},
+		},
+	}).WithoutAmbiguous()
+
+	pass, err := Encode([]byte("ambiguous-check"), charSet, 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.ContainsAny(string(pass), ambiguousChars) {
+		t.Errorf("password %q contains an ambiguous character from %q", pass, ambiguousChars)
+	}
+}
+
+func TestCharSetWithoutQuotes(t *testing.T) {
+	charSet := (&CharSet{
+		Groups: []CharGroup{
+			{Name: "mixed", Chars: "'\"`abc123", Required: true},
+		},
+	}).WithoutQuotes()
+
+	pass, err := Encode([]byte("quote-check"), charSet, 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.ContainsAny(string(pass), quoteChars) {
+		t.Errorf("password %q contains a quote character from %q", pass, quoteChars)
+	}
+}
+
+func TestEncodeRangeIsDeterministic(t *testing.T) {
+	input := []byte("range-check")
+	charSet := DefaultCharSet()
+
+	pass1, err := Encode(input, charSet, 0, Range(12, 20))
+	if err != nil {
+		t.Fatalf("first call error: %v", err)
+	}
+	pass2, err := Encode(input, charSet, 0, Range(12, 20))
+	if err != nil {
+		t.Fatalf("second call error: %v", err)
+	}
+	if string(pass1) != string(pass2) {
+		t.Errorf("non-deterministic output: %q vs %q", pass1, pass2)
+	}
+	if len(pass1) < 12 || len(pass1) > 20 {
+		t.Errorf("password %q has length %d, want between 12 and 20", pass1, len(pass1))
+	}
+	checkRequiredGroups(t, pass1, charSet)
+}
+
+func TestEncodeRangeRejectsInvalidBounds(t *testing.T) {
+	if _, err := Encode([]byte("bad-range"), DefaultCharSet(), 0, Range(20, 12)); err == nil {
+		t.Error("expected an error when max < min")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	input := []byte("verify-check")
+	charSet := DefaultCharSet()
+
+	pass, err := Encode(input, charSet, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := Verify(input, charSet, 16, pass)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("Verify rejected a candidate matching Encode's own output")
+	}
+
+	tampered := append([]byte(nil), pass...)
+	tampered[0]++
+	ok, err = Verify(input, charSet, 16, tampered)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Errorf("Verify accepted a tampered candidate")
+	}
+
+	ok, err = Verify(input, charSet, 16, pass[:len(pass)-1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Errorf("Verify accepted a candidate of the wrong length")
+	}
+}
+
+func TestVerifyForwardsOptions(t *testing.T) {
+	input := []byte("verify-with-context")
+	charSet := DefaultCharSet()
+
+	pass, err := Encode(input, charSet, 16, WithContext([]byte("account-1")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err := Verify(input, charSet, 16, pass, WithContext([]byte("account-1")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("Verify with matching WithContext rejected a valid candidate")
+	}
+
+	ok, err = Verify(input, charSet, 16, pass, WithContext([]byte("account-2")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Errorf("Verify with a different WithContext accepted a candidate derived under a different context")
+	}
+}
+
+// TestEncodeDefaultVsLegacyPRNG checks that the default ChaCha20-backed stream
+// and WithLegacyPRNG both remain deterministic but no longer agree with each
+// other, confirming Encode actually switched its default source.
+func TestEncodeDefaultVsLegacyPRNG(t *testing.T) {
+	input := []byte("legacy-vs-default")
+	charSet := DefaultCharSet()
+
+	def := checkDeterminismWithOpts(t, input, charSet, 16)
+	legacy := checkDeterminismWithOpts(t, input, charSet, 16, WithLegacyPRNG())
+
+	if string(def) == string(legacy) {
+		t.Errorf("expected default and legacy PRNG outputs to differ, both produced %q", def)
+	}
+	checkRequiredGroups(t, def, charSet)
+	checkRequiredGroups(t, legacy, charSet)
+}
+
+// TestEncodeSaltAndContextChangeOutput ensures WithSalt and WithContext are
+// actually folded into the HKDF derivation rather than ignored.
+func TestEncodeSaltAndContextChangeOutput(t *testing.T) {
+	input := []byte("same-input")
+	charSet := DefaultCharSet()
+
+	base, err := Encode(input, charSet, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	withSalt, err := Encode(input, charSet, 16, WithSalt([]byte("salt-a")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	withContext, err := Encode(input, charSet, 16, WithContext([]byte("account-1")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(base) == string(withSalt) {
+		t.Errorf("expected WithSalt to change the output, got the same password %q", base)
+	}
+	if string(base) == string(withContext) {
+		t.Errorf("expected WithContext to change the output, got the same password %q", base)
+	}
+	checkDeterminismWithOpts(t, input, charSet, 16, WithSalt([]byte("salt-a")))
+	checkDeterminismWithOpts(t, input, charSet, 16, WithContext([]byte("account-1")))
+}
+
+// TestChachaStreamIntnUnbiased draws many samples from a group size that
+// doesn't divide a power of two and checks every outcome in [0, n) stays near
+// its expected share, the case where xorshift32's `state % n` is biased.
+func TestChachaStreamIntnUnbiased(t *testing.T) {
+	key, err := deriveKey([]byte("intn-bias-check"), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stream := newChachaStream(key)
+
+	const n = 6 // does not divide any power of two
+	const draws = 2000
+	counts := make([]int, n)
+	for i := 0; i < draws; i++ {
+		v := stream.Intn(n)
+		if v < 0 || v >= n {
+			t.Fatalf("Intn(%d) returned out-of-range value %d", n, v)
+		}
+		counts[v]++
+	}
+	for v, got := range counts {
+		if got == 0 {
+			t.Errorf("value %d was never produced by Intn(%d) across %d draws", v, n, draws)
+		}
+		// Expected share is draws/n (~333); 100 is about six standard deviations.
+		if want := draws / n; got < want-100 || got > want+100 {
+			t.Errorf("value %d was produced %d times by Intn(%d) across %d draws, want about %d", v, got, n, draws, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dgen
+
+import "fmt"
+
+// koremutakeConsonants and koremutakeVowels generate KoremutakeSyllables as a
+// consonant+vowel grid, matching the classic Koremutake scheme of rendering
+// arbitrary bytes as pronounceable syllables.
+var koremutakeConsonants = []string{
+	"b", "d", "f", "g", "h", "j", "k", "l", "m", "n",
+	"p", "r", "s", "t", "v", "w", "x", "z", "ch", "sh",
+	"th", "zh",
+}
+
+var koremutakeVowels = []string{"a", "e", "i", "o", "u", "y"}
+
+// KoremutakeSyllables is the fixed table EncodePronounceable draws syllables
+// from. Callers may replace it wholesale to use a different syllable set, as
+// long as the replacement is non-empty.
+var KoremutakeSyllables = buildKoremutakeSyllables()
+
+// buildKoremutakeSyllables builds the 128-syllable Koremutake-style table
+// ("ba", "be", "bi", "bo", "bu", "by", "da", "de", ...) by pairing each
+// consonant with each vowel in order, stopping once 128 syllables exist.
+func buildKoremutakeSyllables() []string {
+	const size = 128
+	syllables := make([]string, 0, size)
+	for _, c := range koremutakeConsonants {
+		for _, v := range koremutakeVowels {
+			syllables = append(syllables, c+v)
+			if len(syllables) == size {
+				return syllables
+			}
+		}
+	}
+	return syllables
+}
+
+// WithInjectedGroups splices characters from the given groups into an
+// EncodePronounceable result at deterministic positions, so the otherwise
+// alphabetic output can satisfy complexity policies that require digits or
+// symbols. Each group contributes groupMin(group) characters, drawn from the
+// same seeded stream as the syllables. It has no effect on Encode.
+func WithInjectedGroups(groups []CharGroup) Option {
+	return func(o *options) {
+		o.injectGroups = groups
+	}
+}
+
+// injectGroups overwrites groupMin(group) deterministically chosen positions
+// in out with characters from each group, in order. Positions are drawn
+// without replacement from a pool shared across all groups (a partial
+// Fisher–Yates over out's indices), so two draws never collide and silently
+// overwrite one another.
+func injectGroups(out []byte, groups []CharGroup, source randSource) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	totalNeeded := 0
+	for _, group := range groups {
+		if len(group.Chars) == 0 {
+			return fmt.Errorf("injected group %q has no characters", group.Name)
+		}
+		totalNeeded += groupMin(group)
+	}
+	if totalNeeded > len(out) {
+		return fmt.Errorf("cannot inject %d characters into a %d-character password", totalNeeded, len(out))
+	}
+
+	positions := make([]int, len(out))
+	for i := range positions {
+		positions[i] = i
+	}
+
+	drawn := 0
+	for _, group := range groups {
+		for i := 0; i < groupMin(group); i++ {
+			j := drawn + source.Intn(len(positions)-drawn)
+			positions[drawn], positions[j] = positions[j], positions[drawn]
+			pos := positions[drawn]
+			drawn++
+
+			idx := source.Intn(len(group.Chars))
+			out[pos] = group.Chars[idx]
+		}
+	}
+	return nil
+}
+
+// EncodePronounceable deterministically generates a human-pronounceable
+// password by concatenating syllables from KoremutakeSyllables, chosen from
+// the same seeded stream Encode uses, until the output reaches limit bytes;
+// the final syllable is truncated if it would overshoot. Identical input and
+// options always yield an identical password, across runs and platforms.
+func EncodePronounceable(input []byte, limit int, opts ...Option) ([]byte, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("password length %d must be positive", limit)
+	}
+	if len(KoremutakeSyllables) == 0 {
+		return nil, fmt.Errorf("no syllables available: KoremutakeSyllables is empty")
+	}
+
+	cfg := &options{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	source, err := newRandSource(input, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, limit+len(KoremutakeSyllables[0]))
+	for len(out) < limit {
+		idx := source.Intn(len(KoremutakeSyllables))
+		out = append(out, KoremutakeSyllables[idx]...)
+	}
+	out = out[:limit]
+
+	if err := injectGroups(out, cfg.injectGroups, source); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package dgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodePronounceableDeterminism(t *testing.T) {
+	input := []byte("pronounceable-input")
+
+	pass1, err := EncodePronounceable(input, 20)
+	if err != nil {
+		t.Fatalf("first call error: %v", err)
+	}
+	pass2, err := EncodePronounceable(input, 20)
+	if err != nil {
+		t.Fatalf("second call error: %v", err)
+	}
+	if string(pass1) != string(pass2) {
+		t.Errorf("non-deterministic output: %q vs %q", pass1, pass2)
+	}
+	checkPasswordLength(t, pass1, 20)
+}
+
+func TestEncodePronounceableTrimsFinalSyllable(t *testing.T) {
+	for limit := 1; limit <= 10; limit++ {
+		pass, err := EncodePronounceable([]byte("trim-check"), limit)
+		if err != nil {
+			t.Fatalf("limit %d: unexpected error: %v", limit, err)
+		}
+		checkPasswordLength(t, pass, limit)
+	}
+}
+
+func TestEncodePronounceableRejectsNonPositiveLimit(t *testing.T) {
+	if _, err := EncodePronounceable([]byte("x"), 0); err == nil {
+		t.Error("expected an error for a zero length limit")
+	}
+	if _, err := EncodePronounceable([]byte("x"), -1); err == nil {
+		t.Error("expected an error for a negative length limit")
+	}
+}
+
+func TestEncodePronounceableWithInjectedGroups(t *testing.T) {
+	groups := []CharGroup{
+		{Name: "digits", Chars: "0123456789", Min: 2},
+		{Name: "special", Chars: "!@#$%", Min: 1},
+	}
+
+	pass, err := EncodePronounceable([]byte("mixed-input"), 16, WithInjectedGroups(groups))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkPasswordLength(t, pass, 16)
+
+	for _, group := range groups {
+		got := 0
+		for _, c := range pass {
+			if strings.ContainsRune(group.Chars, rune(c)) {
+				got++
+			}
+		}
+		if got < group.Min {
+			t.Errorf("password %q has %d characters from group %q, want at least %d", pass, got, group.Name, group.Min)
+		}
+	}
+}
+
+func TestEncodePronounceableInjectionDoesNotCollide(t *testing.T) {
+	groups := []CharGroup{{Name: "digits", Chars: "0123456789", Min: 8}}
+
+	inputs := []string{"probe-tB", "probe-1", "probe-2", "probe-3", "probe-4", "probe-5", "probe-6", "probe-7"}
+	for _, in := range inputs {
+		pass, err := EncodePronounceable([]byte(in), 10, WithInjectedGroups(groups))
+		if err != nil {
+			t.Fatalf("input %q: unexpected error: %v", in, err)
+		}
+		got := strings.Count(string(pass), "0") + strings.Count(string(pass), "1") + strings.Count(string(pass), "2") +
+			strings.Count(string(pass), "3") + strings.Count(string(pass), "4") + strings.Count(string(pass), "5") +
+			strings.Count(string(pass), "6") + strings.Count(string(pass), "7") + strings.Count(string(pass), "8") +
+			strings.Count(string(pass), "9")
+		if got != 8 {
+			t.Errorf("input %q: password %q has %d digits, want exactly 8", in, pass, got)
+		}
+	}
+}
+
+func TestEncodePronounceableInjectionRejectsTooManyCharacters(t *testing.T) {
+	groups := []CharGroup{{Name: "digits", Chars: "0123456789", Min: 20}}
+	if _, err := EncodePronounceable([]byte("too-many"), 10, WithInjectedGroups(groups)); err == nil {
+		t.Error("expected an error when the requested injection count exceeds the password length")
+	}
+}
+
+func TestEncodePronounceableWithInjectedGroupsIsDeterministic(t *testing.T) {
+	groups := []CharGroup{{Name: "digits", Chars: "0123456789", Min: 3}}
+	input := []byte("deterministic-mix")
+
+	pass1, err := EncodePronounceable(input, 18, WithInjectedGroups(groups))
+	if err != nil {
+		t.Fatalf("first call error: %v", err)
+	}
+	pass2, err := EncodePronounceable(input, 18, WithInjectedGroups(groups))
+	if err != nil {
+		t.Fatalf("second call error: %v", err)
+	}
+	if string(pass1) != string(pass2) {
+		t.Errorf("non-deterministic output: %q vs %q", pass1, pass2)
+	}
+}
+
+func TestKoremutakeSyllablesTable(t *testing.T) {
+	if len(KoremutakeSyllables) != 128 {
+		t.Fatalf("expected 128 syllables, got %d", len(KoremutakeSyllables))
+	}
+	seen := make(map[string]bool, len(KoremutakeSyllables))
+	for _, syllable := range KoremutakeSyllables {
+		if syllable == "" {
+			t.Error("syllable table contains an empty entry")
+		}
+		if seen[syllable] {
+			t.Errorf("syllable %q appears more than once", syllable)
+		}
+		seen[syllable] = true
+	}
+}